@@ -5,67 +5,96 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-
-	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
-const defaultBufferSize = 4096
-
-// Decode decodes objects from a stream until it encounters an EOF. It uses the object
-// registry to discover the types of the objects it decodes.
+// Decode decodes objects from a stream until it encounters an EOF, using
+// DefaultRegistry to discover the types of the objects it decodes. It is a thin
+// wrapper around DefaultRegistry.Decode.
 func Decode(r io.Reader) ([]Object, error) {
-	return decodeWithRegistry(r, Registry)
+	return DefaultRegistry.Decode(r)
 }
 
-// decodeWithRegistry contains the actual logic for decoding objects from a stream. It
-// accepts an ObjectRegistry as an argument to faciliate testing.
-func decodeWithRegistry(r io.Reader, reg ObjectRegistry) ([]Object, error) {
-	var (
-		objs []Object
-		raw  json.RawMessage
-		dec  = yaml.NewYAMLOrJSONDecoder(r, defaultBufferSize)
-	)
-	for {
-		raw = raw[:0]
-		if err := dec.Decode(&raw); err != nil {
-			if err != io.EOF {
-				return nil, fmt.Errorf("unable to decode object: %v", err)
-			}
-			break
-		}
+// Decode decodes objects from a stream until it encounters an EOF, buffering them
+// all into a slice. For large streams, prefer DecodeStream or a Decoder, which
+// process one object at a time.
+func (r *Registry) Decode(rdr io.Reader) ([]Object, error) {
+	var objs []Object
 
-		obj, err := findObject(raw, reg)
+	dec := r.NewDecoder(rdr)
+	for {
+		obj, err := dec.Next()
 		if err != nil {
+			if err == io.EOF {
+				break
+			}
 			return nil, err
 		}
-
-		// The YAMLOrJSONDecoder will convert objects defined in YAML into JSON so `raw` is
-		// guaranteed to hold the JSON representation of the object.
-		if err := json.Unmarshal(raw, &obj); err != nil {
-			return nil, fmt.Errorf("unable to unmarshal object: %v", err)
-		}
-
 		objs = append(objs, obj)
 	}
 
 	return objs, nil
 }
 
-// findObject attempts to find the `type_name` field in a serialized JSON object
-// and uses that information to look up the runtime type of the object in an
-// ObjectRegistry.
-func findObject(data []byte, reg ObjectRegistry) (Object, error) {
+// envelope is the wrapped representation of an object, where the object's type
+// information is kept separate from its payload instead of being inlined as a field
+// on the object itself.
+type envelope struct {
+	Type      string          `json:"type"`
+	Namespace string          `json:"namespace,omitempty"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// findObject determines whether data holds an object in the wrapped envelope format
+// or the inline format, where the `type_name` field sits directly on the object, and
+// uses that information, including the namespace when one is present, to look up the
+// runtime type of the object in reg. It returns the object and its resolved TypeMeta
+// along with the payload that should be unmarshalled into it.
+func findObject(data []byte, reg *Registry) (Object, TypeMeta, json.RawMessage, error) {
+	var probe struct {
+		Type      *string         `json:"type"`
+		Namespace string          `json:"namespace,omitempty"`
+		Value     json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, TypeMeta{}, nil, fmt.Errorf("could not determine object's encoding, json parse error: %v", err)
+	}
+
+	if probe.Type != nil && probe.Value != nil {
+		meta := TypeMeta{TypeName: *probe.Type, Namespace: probe.Namespace}
+		t, ok := reg.Lookup(meta)
+		if !ok {
+			return nil, TypeMeta{}, nil, fmt.Errorf("no registered type found for object with type name: %v", meta.TypeName)
+		}
+		return reflect.New(t).Interface().(Object), meta, probe.Value, nil
+	}
+
 	var meta TypeMeta
 	if err := json.Unmarshal(data, &meta); err != nil {
-		return nil, fmt.Errorf("could not find \"type_name\", json parse error: %v", err)
+		return nil, TypeMeta{}, nil, fmt.Errorf("could not find \"type_name\", json parse error: %v", err)
 	}
 
-	t, ok := reg[meta]
+	t, ok := reg.Lookup(meta)
 	if !ok {
-		return nil, fmt.Errorf("no registered type found for object with type name: %v", meta.TypeName)
+		return nil, TypeMeta{}, nil, fmt.Errorf("no registered type found for object with type name: %v", meta.TypeName)
 	}
 
-	return reflect.New(t).Interface().(Object), nil
+	return reflect.New(t).Interface().(Object), meta, data, nil
+}
+
+// typeSetter is implemented by TypeMeta, and therefore by every Object that embeds
+// it. It lets applyTypeMeta repopulate an object's TypeMeta after unmarshalling.
+type typeSetter interface {
+	setTypeMeta(TypeMeta)
 }
 
+func (obj *TypeMeta) setTypeMeta(meta TypeMeta) { *obj = meta }
 
+// applyTypeMeta sets meta as obj's TypeMeta, if obj supports it. This is required
+// after decoding the wrapped envelope format, since its payload is the `"value"`
+// sub-document and never contains the `type_name`/`namespace` fields that would
+// otherwise populate obj.Type() during json.Unmarshal.
+func applyTypeMeta(obj Object, meta TypeMeta) {
+	if ts, ok := obj.(typeSetter); ok {
+		ts.setTypeMeta(meta)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrappedRoundTripsThroughDecode(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	apple := &Apple{TypeMeta: AppleTypeMeta, Color: "Green"}
+
+	var buf bytes.Buffer
+	if err := (Codec{Mode: Wrapped}).Encode(&buf, apple); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	objs, err := reg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+
+	got, ok := objs[0].(*Apple)
+	if !ok {
+		t.Fatalf("got %T, want *Apple", objs[0])
+	}
+	if got.Color != "Green" {
+		t.Fatalf("Color = %q, want %q", got.Color, "Green")
+	}
+	if got.Type() != AppleTypeMeta {
+		t.Fatalf("Type() = %v, want %v; wrapped envelope decoding didn't repopulate TypeMeta", got.Type(), AppleTypeMeta)
+	}
+}
+
+func TestDecodeHandlesMixedInlineAndWrappedStream(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register Apple: %v", err)
+	}
+	if err := reg.Register(&Banana{}, BananaTypeMeta); err != nil {
+		t.Fatalf("Register Banana: %v", err)
+	}
+
+	input := `{"type_name":"Apple","color":"Red"}
+{"type":"Banana","value":{"ripe":true}}`
+
+	objs, err := reg.Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objs))
+	}
+
+	apple, ok := objs[0].(*Apple)
+	if !ok {
+		t.Fatalf("objs[0]: got %T, want *Apple", objs[0])
+	}
+	if apple.Color != "Red" || apple.Type() != AppleTypeMeta {
+		t.Fatalf("objs[0] = %+v, want Color=Red Type=%v", apple, AppleTypeMeta)
+	}
+
+	banana, ok := objs[1].(*Banana)
+	if !ok {
+		t.Fatalf("objs[1]: got %T, want *Banana", objs[1])
+	}
+	if !banana.Ripe || banana.Type() != BananaTypeMeta {
+		t.Fatalf("objs[1] = %+v, want Ripe=true Type=%v", banana, BananaTypeMeta)
+	}
+}
+
+func TestFindObjectRejectsUnregisteredType(t *testing.T) {
+	reg := NewRegistry()
+	if _, _, _, err := findObject([]byte(`{"type":"Apple","value":{"color":"Red"}}`), reg); err == nil {
+		t.Fatal("findObject succeeded for a type that was never registered")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Mode determines the form a Codec writes objects in. Decoding doesn't use a Mode:
+// it detects the form of each document on its own.
+type Mode int
+
+const (
+	// Inline encodes an object's type name as a `type_name` field embedded directly in
+	// the object, e.g. `{"type_name":"Apple","color":"Red"}`.
+	Inline Mode = iota
+	// Wrapped encodes an object inside an envelope that keeps its type name separate
+	// from its payload, e.g. `{"type":"Apple","value":{"color":"Red"}}`.
+	Wrapped
+)
+
+// Codec encodes objects to a particular Mode. The zero value uses Inline.
+type Codec struct {
+	Mode Mode
+}
+
+// Encode encodes objs to w, one JSON document per object, using the inline
+// `type_name` format. It is a thin wrapper around DefaultRegistry.Encode.
+func Encode(w io.Writer, objs ...Object) error {
+	return DefaultRegistry.Encode(w, objs...)
+}
+
+// Encode encodes objs to w, one JSON document per object, using the inline
+// `type_name` format. It is a method on Registry for symmetry with Decode, though
+// encoding doesn't need to look anything up in r.
+func (r *Registry) Encode(w io.Writer, objs ...Object) error {
+	return Codec{Mode: Inline}.Encode(w, objs...)
+}
+
+// Encode encodes objs to w, one JSON document per object, using c's Mode.
+func (c Codec) Encode(w io.Writer, objs ...Object) error {
+	enc := json.NewEncoder(w)
+	for _, obj := range objs {
+		switch c.Mode {
+		case Wrapped:
+			env, err := wrapObject(obj)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(env); err != nil {
+				return fmt.Errorf("unable to encode object: %v", err)
+			}
+		default:
+			if err := enc.Encode(obj); err != nil {
+				return fmt.Errorf("unable to encode object: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// wrapObject produces the wrapped envelope representation of obj, with its
+// registered type name kept separate from its payload so the two can't collide.
+func wrapObject(obj Object) (envelope, error) {
+	if obj == nil {
+		return envelope{}, fmt.Errorf("unable to marshal object: object is nil")
+	}
+
+	value, err := json.Marshal(obj)
+	if err != nil {
+		return envelope{}, fmt.Errorf("unable to marshal object: %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return envelope{}, fmt.Errorf("unable to marshal object: %v", err)
+	}
+	delete(fields, "type_name")
+	delete(fields, "namespace")
+
+	value, err = json.Marshal(fields)
+	if err != nil {
+		return envelope{}, fmt.Errorf("unable to marshal object: %v", err)
+	}
+
+	meta := obj.Type()
+	return envelope{Type: meta.TypeName, Namespace: meta.Namespace, Value: value}, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Any holds a value of any registered Object type. It lets a field be typed as an
+// interface rather than a single concrete struct, e.g.
+//
+//	type Basket struct {
+//		TypeMeta `json:",inline"`
+//
+//		Fruits []Any `json:"fruits"`
+//	}
+//
+// where Fruits can hold a mix of Apples, Bananas, or any other type registered
+// with MustRegisterObject. Any always round-trips through the wrapped envelope
+// format so the concrete type's registered name travels alongside its payload,
+// since there's no inline field to sniff once the object is nested.
+//
+// Any dispatches on the concrete value's registered TypeMeta rather than on any
+// notion of which Go interface a field is meant to hold: every type registered
+// in the active Registry is eligible for every []Any or Any field, there's no
+// per-interface scoping. A scheme that instead required registering a Go
+// interface once and then its implementations against it would let Register
+// reject, say, a Cherry showing up where only Fruit was expected, but it would
+// also mean a single Object could never serve two unrelated interfaces, and it
+// would need a second registry keyed by interface type alongside the existing
+// one keyed by TypeMeta. Any's fields are the ones already doing that work:
+// Basket.Fruits is typed []Any by convention, not by registration, the same
+// way a field typed []byte doesn't need "byte" registered anywhere.
+//
+// UnmarshalJSON alone can't know which Registry the enclosing decode is using, so
+// Any decodes lazily: UnmarshalJSON only records the raw envelope bytes, and
+// Resolve does the actual type lookup and unmarshalling against a specific
+// Registry. Registry.Decode, Registry.DecodeStream, and Decoder.Next all call
+// Resolve automatically on every Any they find nested in a decoded object, so
+// callers going through this package's decode APIs never need to call it
+// themselves. Any values held in maps are not resolved.
+type Any struct {
+	Object
+
+	raw json.RawMessage
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It records data for later resolution
+// by Resolve; see the Any doc comment for why resolution can't happen here.
+func (a *Any) UnmarshalJSON(data []byte) error {
+	raw := make(json.RawMessage, len(data))
+	copy(raw, data)
+	a.raw = raw
+	return nil
+}
+
+// Resolve looks up the concrete type of a's underlying object in reg by its
+// wrapped type name, constructs it via reflect.New, and unmarshals its payload
+// into it.
+func (a *Any) Resolve(reg *Registry) error {
+	obj, meta, payload, err := findObject(a.raw, reg)
+	if err != nil {
+		return err
+	}
+
+	if err := reg.validatePayload(meta, payload); err != nil {
+		return fmt.Errorf("object failed schema validation: %v", err)
+	}
+
+	if err := json.Unmarshal(payload, obj); err != nil {
+		return fmt.Errorf("unable to unmarshal object: %v", err)
+	}
+	applyTypeMeta(obj, meta)
+
+	if err := resolveAny(obj, reg); err != nil {
+		return err
+	}
+
+	if err := reg.validateObject(meta, obj); err != nil {
+		return fmt.Errorf("object failed validation: %v", err)
+	}
+
+	a.Object = obj
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. It wraps the underlying object in the
+// envelope format so its registered type name is recoverable on decode.
+//
+// MarshalJSON returns an error, rather than panicking, if a's Object is still
+// nil. That happens if a was populated by something other than this package's
+// own decode path, e.g. a plain json.Unmarshal into a struct containing an Any:
+// Any, Object, and TypeMeta are all exported, so that's a reasonable thing for
+// a caller to do, and it leaves UnmarshalJSON's raw bytes unresolved since
+// Resolve is never called.
+func (a Any) MarshalJSON() ([]byte, error) {
+	if a.Object == nil {
+		return nil, fmt.Errorf("unable to marshal Any: underlying Object is unresolved; call Resolve first")
+	}
+
+	env, err := wrapObject(a.Object)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+var anyType = reflect.TypeOf(Any{})
+
+// resolveAny walks obj looking for nested Any values and resolves each of them
+// against reg, so that a field like `Fruits []Any` looks up its concrete types in
+// whichever Registry the enclosing Decode/DecodeStream call is using, rather than
+// always falling back to DefaultRegistry.
+func resolveAny(obj Object, reg *Registry) error {
+	return resolveAnyValue(reflect.ValueOf(obj), reg)
+}
+
+func resolveAnyValue(v reflect.Value, reg *Registry) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveAnyValue(v.Elem(), reg)
+	case reflect.Struct:
+		if v.Type() == anyType {
+			return v.Addr().Interface().(*Any).Resolve(reg)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			if err := resolveAnyValue(v.Field(i), reg); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveAnyValue(v.Index(i), reg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
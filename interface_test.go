@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnyRoundTripsThroughBasket(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register Apple: %v", err)
+	}
+	if err := reg.Register(&Banana{}, BananaTypeMeta); err != nil {
+		t.Fatalf("Register Banana: %v", err)
+	}
+	if err := reg.Register(&Basket{}, BasketTypeMeta); err != nil {
+		t.Fatalf("Register Basket: %v", err)
+	}
+
+	input := `{
+		"type_name": "Basket",
+		"fruits": [
+			{"type": "Apple", "value": {"color": "Red"}},
+			{"type": "Banana", "value": {"ripe": true}}
+		]
+	}`
+
+	objs, err := reg.Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+
+	basket, ok := objs[0].(*Basket)
+	if !ok {
+		t.Fatalf("got %T, want *Basket", objs[0])
+	}
+	if len(basket.Fruits) != 2 {
+		t.Fatalf("got %d fruits, want 2", len(basket.Fruits))
+	}
+
+	apple, ok := basket.Fruits[0].Object.(*Apple)
+	if !ok {
+		t.Fatalf("fruit 0: got %T, want *Apple", basket.Fruits[0].Object)
+	}
+	if apple.Color != "Red" {
+		t.Fatalf("apple.Color = %q, want %q", apple.Color, "Red")
+	}
+	if apple.Type() != AppleTypeMeta {
+		t.Fatalf("apple.Type() = %v, want %v", apple.Type(), AppleTypeMeta)
+	}
+
+	banana, ok := basket.Fruits[1].Object.(*Banana)
+	if !ok {
+		t.Fatalf("fruit 1: got %T, want *Banana", basket.Fruits[1].Object)
+	}
+	if !banana.Ripe {
+		t.Fatal("banana.Ripe = false, want true")
+	}
+
+	var buf bytes.Buffer
+	if err := (Codec{Mode: Wrapped}).Encode(&buf, basket); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	objs, err = reg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode round-trip: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+	roundTripped, ok := objs[0].(*Basket)
+	if !ok {
+		t.Fatalf("got %T, want *Basket", objs[0])
+	}
+	if len(roundTripped.Fruits) != 2 {
+		t.Fatalf("got %d fruits after round-trip, want 2", len(roundTripped.Fruits))
+	}
+}
+
+func TestAnyMarshalJSONErrorsWhenUnresolved(t *testing.T) {
+	var a Any
+	if _, err := a.MarshalJSON(); err == nil {
+		t.Fatal("MarshalJSON succeeded for an Any whose Object was never Resolved")
+	}
+}
+
+func TestWrapObjectErrorsOnNilObject(t *testing.T) {
+	if _, err := wrapObject(nil); err == nil {
+		t.Fatal("wrapObject succeeded for a nil Object")
+	}
+}
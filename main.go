@@ -12,6 +12,15 @@ color: Red
 ---
 type_name: Banana
 ripe: true
+---
+type_name: Basket
+fruits:
+  - type: Apple
+    value:
+      color: Green
+  - type: Banana
+    value:
+      ripe: false
 `
 
 func main() {
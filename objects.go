@@ -1,8 +1,13 @@
 package main
 
 func init() {
-	MustRegisterObject(AppleTypeMeta, &Apple{})
-	MustRegisterObject(BananaTypeMeta, &Banana{})
+	MustRegisterObject(&Apple{}, AppleTypeMeta)
+	MustRegisterObject(&Banana{}, BananaTypeMeta)
+	MustRegisterObject(&Basket{}, BasketTypeMeta)
+
+	// Cherry has no explicit TypeMeta, so its wire name is derived from its Go
+	// type: "main.Cherry".
+	MustRegisterObject(&Cherry{})
 }
 
 // AppleTypeName is the type name of an Apple object.
@@ -30,3 +35,25 @@ type Banana struct {
 
 	Ripe bool `json:"ripe"`
 }
+
+// BasketTypeName is the type name of a Basket object.
+const BasketTypeName = "Basket"
+
+// BasketTypeMeta is the type information for a Basket object.
+var BasketTypeMeta = TypeMeta{TypeName: BasketTypeName}
+
+// Basket is an example object demonstrating a field that can hold a mix of other
+// registered object types.
+type Basket struct {
+	TypeMeta `json:",inline"`
+
+	Fruits []Any `json:"fruits"`
+}
+
+// Cherry is an example object demonstrating registration without an explicit
+// TypeMeta.
+type Cherry struct {
+	TypeMeta `json:",inline"`
+
+	Pitted bool `json:"pitted"`
+}
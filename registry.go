@@ -4,34 +4,70 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-)
+	"sync"
 
-var (
-	errTypeNameMissing = errors.New("cannot register an Object that doesn't have a TypeName")
-	errInvalidType     = errors.New("can only register types that are pointers to structs")
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
+var errInvalidType = errors.New("can only register types that are pointers to structs")
+
 // ObjectRegistry is a map from the name of an object's type to it's actual Go type.
 type ObjectRegistry = map[TypeMeta]reflect.Type
 
-// Registry contains the type information for all registered objects.
-var Registry = make(ObjectRegistry)
+// Registry holds the type information for a set of registered objects. A Registry
+// is safe for concurrent use. Its zero value is not usable; construct one with
+// NewRegistry.
+type Registry struct {
+	mu         sync.RWMutex
+	types      ObjectRegistry
+	validators map[TypeMeta]func(Object) error
+	schemas    map[TypeMeta]*jsonschema.Schema
+	parent     *Registry
+}
 
-// MustRegisterObject registers an object. `meta` must define the object's type
-// and there cannot be a different object with the same name in Registry already.
-// If any of these conditions aren't met the function will panic. MustRegisterObject
-// is intended to be called in init functions to register all valid types at startup.
-func MustRegisterObject(meta TypeMeta, obj Object) {
-	if err := registerObject(meta, obj, Registry); err != nil {
-		panic(fmt.Sprintf("Unable to register Object: %v.", err))
+// NewRegistry returns an empty Registry. If a parent is given, Lookup falls back
+// to it whenever a type isn't found locally, so callers can compose disjoint type
+// universes, e.g. a per-tenant or per-test Registry layered on top of a shared set
+// of base types.
+func NewRegistry(parent ...*Registry) *Registry {
+	r := &Registry{
+		types:      make(ObjectRegistry),
+		validators: make(map[TypeMeta]func(Object) error),
+		schemas:    make(map[TypeMeta]*jsonschema.Schema),
 	}
+	if len(parent) > 0 {
+		r.parent = parent[0]
+	}
+	return r
 }
 
-// registerObject contains the actual logic for registering an Object in an ObjectRegistry.
-func registerObject(meta TypeMeta, obj Object, r ObjectRegistry) error {
-	if meta.TypeName == "" {
-		return errTypeNameMissing
+// DefaultRegistry is the Registry used by the package-level MustRegisterObject,
+// Decode, and Encode functions.
+var DefaultRegistry = NewRegistry()
+
+// MustRegisterObject registers an object in DefaultRegistry. See Registry.MustRegister.
+func MustRegisterObject(obj Object, meta ...TypeMeta) {
+	DefaultRegistry.MustRegister(obj, meta...)
+}
+
+// MustRegister registers an object, deriving its type name with defaultTypeName
+// unless an explicit meta is given. There cannot be a different object already
+// registered under the same TypeMeta in r; if there is, the function will panic.
+// MustRegister is intended to be called in init functions to register all valid
+// types at startup.
+func (r *Registry) MustRegister(obj Object, meta ...TypeMeta) {
+	if err := r.Register(obj, meta...); err != nil {
+		panic(fmt.Sprintf("Unable to register Object: %v.", err))
 	}
+}
+
+// Register registers obj in r. If meta is given, it is used as-is, except that an
+// empty TypeName is still replaced by defaultTypeName(obj); this lets a caller set
+// only a Namespace and leave the name itself to be derived. Register returns an
+// error if obj isn't a pointer to a struct, or if a different type is already
+// registered under the resulting TypeMeta.
+func (r *Registry) Register(obj Object, meta ...TypeMeta) error {
+	m := resolveMeta(obj, meta...)
 
 	t := reflect.TypeOf(obj)
 	if t.Kind() != reflect.Ptr {
@@ -42,13 +78,57 @@ func registerObject(meta TypeMeta, obj Object, r ObjectRegistry) error {
 		return errInvalidType
 	}
 
-	if oldT, found := r[meta]; found && oldT != t {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if oldT, found := r.types[m]; found && oldT != t {
 		return fmt.Errorf(
 			"Double registration of different types for %v: old=%v.%v, new=%v.%v",
-			meta, oldT.PkgPath(), oldT.Name(), t.PkgPath(), t.Name(),
+			m, oldT.PkgPath(), oldT.Name(), t.PkgPath(), t.Name(),
 		)
 	}
-	r[meta] = t
+	r.types[m] = t
 
 	return nil
 }
+
+// defaultTypeName derives a wire name for obj from its Go type, in the form
+// "<package path>.<type name>", e.g. "main.Cherry" for Cherry in this package main.
+// It lets callers register a type without supplying an explicit TypeMeta.
+func defaultTypeName(obj Object) string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// resolveMeta returns the TypeMeta that obj should be registered under: meta[0] if
+// given, falling back to defaultTypeName(obj) whenever TypeName is empty.
+func resolveMeta(obj Object, meta ...TypeMeta) TypeMeta {
+	var m TypeMeta
+	if len(meta) > 0 {
+		m = meta[0]
+	}
+	if m.TypeName == "" {
+		m.TypeName = defaultTypeName(obj)
+	}
+	return m
+}
+
+// Lookup returns the Go type registered under meta. If meta isn't found in r and r
+// has a parent, Lookup falls back to consulting it.
+func (r *Registry) Lookup(meta TypeMeta) (reflect.Type, bool) {
+	r.mu.RLock()
+	t, ok := r.types[meta]
+	r.mu.RUnlock()
+	if ok {
+		return t, true
+	}
+
+	if r.parent != nil {
+		return r.parent.Lookup(meta)
+	}
+
+	return nil, false
+}
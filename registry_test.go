@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type gizmo struct {
+	TypeMeta `json:",inline"`
+}
+
+func TestDefaultTypeNameDerivesFromGoType(t *testing.T) {
+	if got, want := defaultTypeName(&Cherry{}), "main.Cherry"; got != want {
+		t.Fatalf("defaultTypeName(&Cherry{}) = %q, want %q", got, want)
+	}
+
+	// Cherry is registered in objects.go's init() with no explicit TypeMeta, so
+	// DefaultRegistry should have it filed under the derived name.
+	if _, ok := DefaultRegistry.Lookup(TypeMeta{TypeName: "main.Cherry"}); !ok {
+		t.Fatal(`DefaultRegistry.Lookup(TypeMeta{TypeName: "main.Cherry"}): not found`)
+	}
+}
+
+type widgetA struct {
+	TypeMeta `json:",inline"`
+
+	A string `json:"a"`
+}
+
+type widgetB struct {
+	TypeMeta `json:",inline"`
+
+	B string `json:"b"`
+}
+
+func TestNamespaceDisambiguatesSameTypeName(t *testing.T) {
+	reg := NewRegistry()
+	metaA := TypeMeta{TypeName: "Widget", Namespace: "a"}
+	metaB := TypeMeta{TypeName: "Widget", Namespace: "b"}
+	if err := reg.Register(&widgetA{}, metaA); err != nil {
+		t.Fatalf("Register widgetA: %v", err)
+	}
+	if err := reg.Register(&widgetB{}, metaB); err != nil {
+		t.Fatalf("Register widgetB: %v", err)
+	}
+
+	objs, err := reg.Decode(strings.NewReader(
+		`{"type_name":"Widget","namespace":"a","a":"x"}
+{"type":"Widget","namespace":"b","value":{"b":"y"}}`,
+	))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objs))
+	}
+
+	gotA, ok := objs[0].(*widgetA)
+	if !ok {
+		t.Fatalf("objs[0]: got %T, want *widgetA", objs[0])
+	}
+	if gotA.A != "x" {
+		t.Fatalf("widgetA.A = %q, want %q", gotA.A, "x")
+	}
+
+	gotB, ok := objs[1].(*widgetB)
+	if !ok {
+		t.Fatalf("objs[1]: got %T, want *widgetB", objs[1])
+	}
+	if gotB.B != "y" {
+		t.Fatalf("widgetB.B = %q, want %q", gotB.B, "y")
+	}
+}
+
+func TestRegisterAndLookupConcurrently(t *testing.T) {
+	reg := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			meta := TypeMeta{TypeName: fmt.Sprintf("Gizmo%d", i)}
+			if err := reg.Register(&gizmo{}, meta); err != nil {
+				t.Errorf("Register: %v", err)
+				return
+			}
+			if _, ok := reg.Lookup(meta); !ok {
+				t.Errorf("Lookup(%v): not found", meta)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLookupFallsBackToParentRegistry(t *testing.T) {
+	base := NewRegistry()
+	if err := base.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	child := NewRegistry(base)
+
+	if _, ok := child.Lookup(AppleTypeMeta); !ok {
+		t.Fatal("Lookup did not fall back to the parent registry")
+	}
+
+	if err := child.Register(&Banana{}, BananaTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, ok := base.Lookup(BananaTypeMeta); ok {
+		t.Fatal("a type registered on a child registry leaked into its parent")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const defaultBufferSize = 4096
+
+// Decoder decodes a stream of objects one at a time, using a Registry to discover
+// their types. Unlike Decode, it doesn't buffer the whole stream into memory, which
+// makes it suitable for large YAML/JSON streams.
+type Decoder struct {
+	reg *Registry
+	dec *yaml.YAMLOrJSONDecoder
+	raw json.RawMessage
+}
+
+// NewDecoder returns a Decoder that reads from r using DefaultRegistry to discover
+// object types. bufferSize overrides the underlying YAMLOrJSONDecoder's buffer size,
+// which otherwise defaults to defaultBufferSize.
+func NewDecoder(r io.Reader, bufferSize ...int) *Decoder {
+	return DefaultRegistry.NewDecoder(r, bufferSize...)
+}
+
+// NewDecoder returns a Decoder that reads from r using reg to discover object
+// types. bufferSize overrides the underlying YAMLOrJSONDecoder's buffer size, which
+// otherwise defaults to defaultBufferSize.
+func (reg *Registry) NewDecoder(r io.Reader, bufferSize ...int) *Decoder {
+	size := defaultBufferSize
+	if len(bufferSize) > 0 {
+		size = bufferSize[0]
+	}
+	return &Decoder{reg: reg, dec: yaml.NewYAMLOrJSONDecoder(r, size)}
+}
+
+// Next decodes and returns the next object in the stream. It returns io.EOF once the
+// stream is exhausted.
+func (d *Decoder) Next() (Object, error) {
+	d.raw = d.raw[:0]
+	if err := d.dec.Decode(&d.raw); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("unable to decode object: %v", err)
+	}
+
+	obj, meta, payload, err := findObject(d.raw, d.reg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.reg.validatePayload(meta, payload); err != nil {
+		return nil, fmt.Errorf("object failed schema validation: %v", err)
+	}
+
+	// The YAMLOrJSONDecoder will convert objects defined in YAML into JSON so `payload`
+	// is guaranteed to hold the JSON representation of the object.
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal object: %v", err)
+	}
+	applyTypeMeta(obj, meta)
+
+	if err := resolveAny(obj, d.reg); err != nil {
+		return nil, err
+	}
+
+	if err := d.reg.validateObject(meta, obj); err != nil {
+		return nil, fmt.Errorf("object failed validation: %v", err)
+	}
+
+	return obj, nil
+}
+
+// DecodeStream decodes objects from r one at a time, using DefaultRegistry to
+// discover their types, invoking fn for each. It is a thin wrapper around
+// DefaultRegistry.DecodeStream.
+func DecodeStream(r io.Reader, fn func(Object) error, bufferSize ...int) error {
+	return DefaultRegistry.DecodeStream(r, fn, bufferSize...)
+}
+
+// DecodeStream decodes objects from r one at a time, invoking fn for each. It stops
+// and returns fn's error as soon as fn returns a non-nil error, without decoding any
+// further objects. bufferSize overrides the underlying YAMLOrJSONDecoder's buffer
+// size, which otherwise defaults to defaultBufferSize.
+func (r *Registry) DecodeStream(rdr io.Reader, fn func(Object) error, bufferSize ...int) error {
+	dec := r.NewDecoder(rdr, bufferSize...)
+	for {
+		obj, err := dec.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStreamStopsOnFnError(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	input := `{"type_name":"Apple","color":"Red"}
+{"type_name":"Apple","color":"Green"}
+{"type_name":"Apple","color":"Blue"}`
+
+	errStop := errors.New("stop")
+	var seen []string
+	err := reg.DecodeStream(strings.NewReader(input), func(obj Object) error {
+		seen = append(seen, obj.(*Apple).Color)
+		if len(seen) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("DecodeStream returned %v, want %v", err, errStop)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("decoded %d objects before stopping, want 2", len(seen))
+	}
+}
+
+func TestDecoderNextReturnsEOF(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	dec := reg.NewDecoder(strings.NewReader(`{"type_name":"Apple","color":"Red"}`))
+
+	if _, err := dec.Next(); err != nil {
+		t.Fatalf("first Next: %v", err)
+	}
+
+	if _, err := dec.Next(); err != io.EOF {
+		t.Fatalf("second Next returned %v, want io.EOF", err)
+	}
+}
+
+func TestNewDecoderHonorsBufferSizeOverride(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&Apple{}, AppleTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	input := `{"type_name":"Apple","color":` + `"` + strings.Repeat("x", 100) + `"}`
+
+	// A buffer smaller than the document should fail to decode it.
+	dec := reg.NewDecoder(strings.NewReader(input), 16)
+	if _, err := dec.Next(); err == nil {
+		t.Fatal("Next succeeded with a buffer too small to hold the document")
+	}
+
+	// The default buffer size (and an explicit large override) should handle it fine.
+	dec = reg.NewDecoder(strings.NewReader(input))
+	if _, err := dec.Next(); err != nil {
+		t.Fatalf("Next with default buffer size: %v", err)
+	}
+
+	dec = reg.NewDecoder(strings.NewReader(input), len(input)*2)
+	if _, err := dec.Next(); err != nil {
+		t.Fatalf("Next with an explicit large buffer size: %v", err)
+	}
+}
@@ -4,6 +4,10 @@ package main
 type TypeMeta struct {
 	// TypeName is the name of an object's type.
 	TypeName string `json:"type_name,omitempty"`
+
+	// Namespace optionally scopes TypeName, so that two packages can each register
+	// their own "Foo" without colliding in the registry.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // Object is the interface that all types must fulfill.
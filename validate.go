@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// RegisterWithValidator registers obj in DefaultRegistry. See
+// Registry.RegisterWithValidator.
+func RegisterWithValidator(obj Object, v func(Object) error, meta ...TypeMeta) error {
+	return DefaultRegistry.RegisterWithValidator(obj, v, meta...)
+}
+
+// RegisterWithValidator registers obj like Register, additionally attaching v as a
+// validator. v is run against the decoded object immediately after it's
+// unmarshalled, so it can enforce invariants that aren't expressible as JSON Schema,
+// e.g. cross-field checks.
+func (r *Registry) RegisterWithValidator(obj Object, v func(Object) error, meta ...TypeMeta) error {
+	m := resolveMeta(obj, meta...)
+	if err := r.Register(obj, m); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.validators[m] = v
+	r.mu.Unlock()
+
+	return nil
+}
+
+// RegisterSchema attaches a JSON Schema to the type already registered under meta,
+// so that the raw payload of an incoming object is validated against it before
+// being unmarshalled. schema may be either raw JSON Schema document bytes or an
+// already-compiled *jsonschema.Schema.
+func (r *Registry) RegisterSchema(meta TypeMeta, schema interface{}) error {
+	if _, ok := r.Lookup(meta); !ok {
+		return fmt.Errorf("no type registered for %v", meta)
+	}
+
+	compiled, ok := schema.(*jsonschema.Schema)
+	if !ok {
+		raw, ok := schema.([]byte)
+		if !ok {
+			return fmt.Errorf("schema must be []byte or *jsonschema.Schema, got %T", schema)
+		}
+
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(meta.TypeName, bytes.NewReader(raw)); err != nil {
+			return fmt.Errorf("unable to add schema for %v: %v", meta, err)
+		}
+		c, err := compiler.Compile(meta.TypeName)
+		if err != nil {
+			return fmt.Errorf("unable to compile schema for %v: %v", meta, err)
+		}
+		compiled = c
+	}
+
+	r.mu.Lock()
+	r.schemas[meta] = compiled
+	r.mu.Unlock()
+
+	return nil
+}
+
+// validatePayload validates the raw, not-yet-unmarshalled payload of an object
+// against its registered JSON Schema, if any.
+func (r *Registry) validatePayload(meta TypeMeta, payload []byte) error {
+	schema, ok := r.lookupSchema(meta)
+	if !ok {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return fmt.Errorf("unable to unmarshal payload for validation: %v", err)
+	}
+
+	return schema.Validate(v)
+}
+
+// lookupSchema returns the JSON Schema registered under meta, consulting r's
+// parent registries (if any) when meta isn't found locally, the same way Lookup
+// does for types.
+func (r *Registry) lookupSchema(meta TypeMeta) (*jsonschema.Schema, bool) {
+	r.mu.RLock()
+	schema, ok := r.schemas[meta]
+	r.mu.RUnlock()
+	if ok {
+		return schema, true
+	}
+
+	if r.parent != nil {
+		return r.parent.lookupSchema(meta)
+	}
+
+	return nil, false
+}
+
+// validateObject runs the validator registered for meta, if any, against the
+// already-unmarshalled object.
+func (r *Registry) validateObject(meta TypeMeta, obj Object) error {
+	v, ok := r.lookupValidator(meta)
+	if !ok {
+		return nil
+	}
+
+	return v(obj)
+}
+
+// lookupValidator returns the validator registered under meta, consulting r's
+// parent registries (if any) when meta isn't found locally, the same way Lookup
+// does for types.
+func (r *Registry) lookupValidator(meta TypeMeta) (func(Object) error, bool) {
+	r.mu.RLock()
+	v, ok := r.validators[meta]
+	r.mu.RUnlock()
+	if ok {
+		return v, true
+	}
+
+	if r.parent != nil {
+		return r.parent.lookupValidator(meta)
+	}
+
+	return nil, false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type widget struct {
+	TypeMeta `json:",inline"`
+
+	Count int `json:"count"`
+}
+
+var widgetTypeMeta = TypeMeta{TypeName: "Widget"}
+
+func TestRegisterWithValidatorRejectsInvalidObject(t *testing.T) {
+	reg := NewRegistry()
+	err := reg.RegisterWithValidator(&widget{}, func(obj Object) error {
+		if obj.(*widget).Count < 0 {
+			return errors.New("count must be non-negative")
+		}
+		return nil
+	}, widgetTypeMeta)
+	if err != nil {
+		t.Fatalf("RegisterWithValidator: %v", err)
+	}
+
+	if _, err := reg.Decode(strings.NewReader(`{"type_name":"Widget","count":-1}`)); err == nil {
+		t.Fatal("Decode succeeded for an object that should have failed validation")
+	}
+
+	objs, err := reg.Decode(strings.NewReader(`{"type_name":"Widget","count":1}`))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+}
+
+func TestRegisterSchemaRejectsInvalidPayload(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(&widget{}, widgetTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	schema := []byte(`{
+		"type": "object",
+		"properties": {"count": {"type": "integer", "minimum": 0}},
+		"required": ["count"]
+	}`)
+	if err := reg.RegisterSchema(widgetTypeMeta, schema); err != nil {
+		t.Fatalf("RegisterSchema: %v", err)
+	}
+
+	if _, err := reg.Decode(strings.NewReader(`{"type_name":"Widget","count":-1}`)); err == nil {
+		t.Fatal("Decode succeeded for a payload that should have failed schema validation")
+	}
+
+	objs, err := reg.Decode(strings.NewReader(`{"type_name":"Widget","count":1}`))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+}
+
+func TestSchemaAndValidatorFallBackToParentRegistry(t *testing.T) {
+	base := NewRegistry()
+	if err := base.Register(&widget{}, widgetTypeMeta); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	schema := []byte(`{
+		"type": "object",
+		"properties": {"count": {"type": "integer", "minimum": 0}},
+		"required": ["count"]
+	}`)
+	if err := base.RegisterSchema(widgetTypeMeta, schema); err != nil {
+		t.Fatalf("RegisterSchema: %v", err)
+	}
+
+	child := NewRegistry(base)
+
+	if _, err := child.Decode(strings.NewReader(`{"type_name":"Widget","count":-1}`)); err == nil {
+		t.Fatal("a child registry should inherit its parent's schema validation")
+	}
+
+	err := base.RegisterWithValidator(&widget{}, func(obj Object) error {
+		if obj.(*widget).Count > 100 {
+			return errors.New("count must be at most 100")
+		}
+		return nil
+	}, widgetTypeMeta)
+	if err != nil {
+		t.Fatalf("RegisterWithValidator: %v", err)
+	}
+
+	if _, err := child.Decode(strings.NewReader(`{"type_name":"Widget","count":101}`)); err == nil {
+		t.Fatal("a child registry should inherit its parent's validator")
+	}
+}